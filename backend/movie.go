@@ -11,6 +11,32 @@ type Movie struct {
 	Rating  float64
 	Year    int
 	Poster  NullString // Explanation below
+
+	// The fields below are populated from OMDb once the movie has been
+	// enriched via refreshMovieMetadata. They are all nullable because a
+	// movie may have been added without ever being enriched.
+	Rated      NullString
+	Released   NullString
+	Runtime    NullString
+	Genre      NullString
+	Director   NullString
+	Writer     NullString
+	Actors     NullString
+	Plot       NullString
+	Language   NullString
+	Country    NullString
+	Awards     NullString
+	Metascore  NullString
+	ImdbRating NullFloat64
+	ImdbVotes  NullString
+	Type       NullString
+	Ratings    []OMDBRating `json:"Ratings,omitempty"`
+
+	// UserRating and DateRated are populated from an IMDb ratings export via
+	// importIMDbCSV. They're nullable because most movies are added without
+	// ever being imported from a CSV.
+	UserRating NullFloat64
+	DateRated  NullString
 }
 
 // What follows here is an explanation of the custom NullString type you can
@@ -65,3 +91,34 @@ func (ns *NullString) UnmarshalJSON(data []byte) error {
 	}
 	return nil
 }
+
+// NullFloat64 is the float64 equivalent of NullString: a sql.NullFloat64
+// that marshals as the bare number when present, or as `null` when absent,
+// instead of the `{Float64: 0, Valid: false}` shape sql.NullFloat64 gives by
+// default.
+type NullFloat64 struct {
+	sql.NullFloat64
+}
+
+// Show the float directly as value if there is one, otherwise show `null`
+func (nf NullFloat64) MarshalJSON() ([]byte, error) {
+	if nf.Valid {
+		return json.Marshal(nf.Float64)
+	}
+	return json.Marshal(nil)
+}
+
+// Unwrap a value into the original sql.NullFloat64 type.
+func (nf *NullFloat64) UnmarshalJSON(data []byte) error {
+	var f *float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	if f != nil {
+		nf.Valid = true
+		nf.Float64 = *f
+	} else {
+		nf.Valid = false
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"os"
+	"time"
 )
 
 // handleAddMovieCLI adds a movie to the database with the given
@@ -59,17 +61,114 @@ func handleDeleteMovieCLI(db *sql.DB, imdbID string) {
 	fmt.Println("Movie deleted")
 }
 
-// handleFetchPostersCLI fetches movie posters concurrently for a specified
-// number of movies without posters in the database. It takes a database
-// connection and a limit on the number of movies to process. If an error
-// occurs during the fetching process, it prints an error message.
+// handleFetchPostersCLI fetches movie posters for a specified number of
+// movies without posters in the database. It enqueues a fetch_posters job
+// on the global job queue and blocks, polling the job's status, until it
+// finishes. If the job fails, it prints an error message.
 func handleFetchPostersCLI(db *sql.DB, limit int) {
-	const workerCount = 3
-	err := fetchPostersConcurrently(db, workerCount, limit)
+	job, err := globalJobQueue.Enqueue(JobKindFetchPosters, limit)
 	if err != nil {
 		fmt.Println("Error fetching posters:", err)
 		return
 	}
+
+	for {
+		job, ok := globalJobQueue.Get(job.ID)
+		if !ok || job.Status == JobStatusDone {
+			return
+		}
+		if job.Status == JobStatusFailed {
+			fmt.Println("Error fetching posters:", job.LastError)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// handleEnrichMovieCLI refreshes the full OMDb metadata for a single movie
+// with the given IMDb ID. If the lookup or database update fails, it prints
+// an error message. Otherwise, it prints a success message.
+func handleEnrichMovieCLI(db *sql.DB, imdbID string) {
+	err := refreshMovieMetadata(db, imdbID)
+	if err != nil {
+		fmt.Println("Error enriching movie:", err)
+		return
+	}
+	fmt.Println("Movie metadata enriched")
+}
+
+// handleEnrichMoviesCLI enriches metadata for up to limit movies that have
+// never been enriched, using a pool of worker goroutines. If an error
+// occurs while querying the database, it prints an error message.
+func handleEnrichMoviesCLI(db *sql.DB, limit int) {
+	const workerCount = 3
+	err := enrichMoviesConcurrently(db, workerCount, limit, nil)
+	if err != nil {
+		fmt.Println("Error enriching movies:", err)
+		return
+	}
+}
+
+// handleSearchMoviesCLI full-text searches movies by title, plot, actors,
+// genre, and director. If the search fails, it prints an error message.
+// Otherwise, it prints the title and a matching snippet for each result.
+func handleSearchMoviesCLI(db *sql.DB, query string, limit, offset int) {
+	results, err := searchMovies(db, query, limit, offset)
+	if err != nil {
+		fmt.Println("Error searching movies:", err)
+		return
+	}
+	for _, result := range results {
+		fmt.Printf("%s: %s\n", result.Title, result.Snippet)
+	}
+}
+
+// handleImportMoviesCLI imports movies from the IMDb watchlist or ratings
+// CSV export at path, printing how many rows were added and skipped. If
+// any movies were added, it enqueues an enrich_metadata job with a limit
+// equal to that count, which enriches whichever movies have no Plot on file
+// (not necessarily the imported ones), and blocks, polling the job's status, until it
+// finishes, so the enrichment has actually run by the time the command
+// exits and db.Close()s the queue's workers out from under it.
+func handleImportMoviesCLI(db *sql.DB, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Println("Error opening import file:", err)
+		return
+	}
+	defer file.Close()
+
+	added, skipped, errs, err := importIMDbCSV(db, file)
+	if err != nil {
+		fmt.Println("Error importing movies:", err)
+		return
+	}
+	fmt.Printf("Imported %d movies (%d skipped)\n", added, skipped)
+	for _, rowErr := range errs {
+		fmt.Println("Skipped row:", rowErr)
+	}
+
+	if added == 0 {
+		return
+	}
+
+	job, err := globalJobQueue.Enqueue(JobKindEnrichMetadata, added)
+	if err != nil {
+		fmt.Println("Error enqueueing enrichment job:", err)
+		return
+	}
+
+	for {
+		job, ok := globalJobQueue.Get(job.ID)
+		if !ok || job.Status == JobStatusDone {
+			return
+		}
+		if job.Status == JobStatusFailed {
+			fmt.Println("Error enriching imported movies:", job.LastError)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
 }
 
 func handleUpdatePosterCLI(db *sql.DB, imdbID, posterURL string) {
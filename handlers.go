@@ -42,7 +42,7 @@ func enableCORS(next http.Handler) http.Handler {
 func handleAddMovie(w http.ResponseWriter, r *http.Request) {
 	var movie Movie
 	if err := json.NewDecoder(r.Body).Decode(&movie); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, "Invalid input", err)
 		return
 	}
 
@@ -50,22 +50,23 @@ func handleAddMovie(w http.ResponseWriter, r *http.Request) {
 
 	db, err := openDB()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "Could not open database", err)
 		return
 	}
 	defer db.Close()
 
 	if movie.IMDb_id == "" || movie.Title == "" || movie.Year == 0 || movie.Rating == 0 {
-		http.Error(w, "Missing required fields: IMDb ID, Title, Year, or Rating", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, "Missing required fields: IMDb ID, Title, Year, or Rating", nil)
 		return
 	}
 
 	err = addMovie(db, movie.IMDb_id, movie.Title, movie.Year, movie.Rating)
 	if err != nil {
-		http.Error(w, "Could not add movie"+err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "Could not add movie", err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(movie)
 }
@@ -87,7 +88,7 @@ func handleAddMovie(w http.ResponseWriter, r *http.Request) {
 func handleListMovies(w http.ResponseWriter, r *http.Request) {
 	db, err := openDB()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "Could not open database", err)
 		return
 	}
 	defer db.Close()
@@ -104,7 +105,7 @@ func handleListMovies(w http.ResponseWriter, r *http.Request) {
 
 	movies, err := listMovies(db, sortBy, order, filterYear)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "Could not list movies", err)
 		return
 	}
 
@@ -122,14 +123,14 @@ func handleMovieDetails(w http.ResponseWriter, r *http.Request) {
 
 	db, err := openDB()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "Could not open database", err)
 		return
 	}
 	defer db.Close()
 
 	movie, err := showMovieDetails(db, imdbID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, r, http.StatusNotFound, "Movie not found", err)
 		return
 	}
 
@@ -137,20 +138,182 @@ func handleMovieDetails(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(movie)
 }
 
+// handleRefreshMovieMetadata handles HTTP POST requests to
+// /movies/{imdbID}/refresh. It fetches the full OMDb record for the given
+// IMDb ID and stores it on the matching movie. If the database cannot be
+// opened, the movie doesn't exist, or the OMDb lookup fails, it returns an
+// HTTP error. Otherwise, it returns the refreshed movie in JSON format.
+func handleRefreshMovieMetadata(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	imdbID := vars["imdbID"]
+
+	db, err := openDB()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "Could not open database", err)
+		return
+	}
+	defer db.Close()
+
+	if err := refreshMovieMetadata(db, imdbID); err != nil {
+		writeError(w, r, http.StatusBadGateway, "Could not refresh movie metadata", err)
+		return
+	}
+
+	movie, err := showMovieDetails(db, imdbID)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "Movie not found", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movie)
+}
+
+// handleSearchMovies handles HTTP GET requests to /movies/search. The query
+// parameters are:
+//
+//   - `q`: the search terms. Required.
+//   - `limit`: the maximum number of results to return. Defaults to 20.
+//   - `offset`: the number of results to skip, for pagination. Defaults to 0.
+//
+// If the database cannot be opened or the search fails, it returns an HTTP
+// error. Otherwise, it returns the matching movies with highlighted
+// snippets in JSON format with the HTTP status 200 OK.
+func handleSearchMovies(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	q := queryParams.Get("q")
+	if q == "" {
+		writeError(w, r, http.StatusBadRequest, "Missing required query parameter: q", nil)
+		return
+	}
+
+	limit := 20
+	if v := queryParams.Get("limit"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			limit = parsed
+		}
+	}
+
+	offset := 0
+	if v := queryParams.Get("offset"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			offset = parsed
+		}
+	}
+
+	db, err := openDB()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "Could not open database", err)
+		return
+	}
+	defer db.Close()
+
+	results, err := searchMovies(db, q, limit, offset)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "Could not search movies", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// handleImportMovies handles HTTP POST requests to /movies/import. It
+// expects a multipart form with an IMDb watchlist or ratings CSV export
+// under the "file" field, streams it through importIMDbCSV, and, if any
+// rows were added, enqueues an enrich_metadata job with a limit equal to
+// that count. The job enriches whichever movies have no Plot on file; it is
+// not restricted to the imported IDs. If the database
+// cannot be opened or no file was uploaded, it returns an HTTP error.
+// Otherwise, it returns a JSON summary of the import with HTTP status 200
+// OK, even if some rows were skipped.
+func handleImportMovies(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "Missing file upload", err)
+		return
+	}
+	defer file.Close()
+
+	db, err := openDB()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "Could not open database", err)
+		return
+	}
+	defer db.Close()
+
+	added, skipped, errs, err := importIMDbCSV(db, file)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "Could not import movies", err)
+		return
+	}
+
+	if added > 0 {
+		if _, err := globalJobQueue.Enqueue(JobKindEnrichMetadata, added); err != nil {
+			fmt.Println("Error enqueueing enrichment job:", err)
+		}
+	}
+
+	if errs == nil {
+		errs = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Added   int      `json:"added"`
+		Skipped int      `json:"skipped"`
+		Errors  []string `json:"errors"`
+	}{Added: added, Skipped: skipped, Errors: errs})
+}
+
+// handleMovieReviews handles HTTP GET requests to /movies/{imdbID}/reviews.
+// It returns any reviews already stored for the movie; if there are none
+// yet, it fetches them via defaultProvider and stores them before
+// returning. If the database cannot be opened or no reviews can be found
+// anywhere, it returns an HTTP error.
+func handleMovieReviews(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	imdbID := vars["imdbID"]
+
+	db, err := openDB()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "Could not open database", err)
+		return
+	}
+	defer db.Close()
+
+	reviews, err := listReviews(db, imdbID)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "Could not list reviews", err)
+		return
+	}
+
+	if len(reviews) == 0 {
+		reviews, err = fetchAndStoreReviews(db, imdbID)
+		if err != nil {
+			writeError(w, r, http.StatusNotFound, "No reviews found", err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reviews)
+}
+
 func handleDeleteMovie(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	imdbID := vars["imdbID"]
 
 	db, err := openDB()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "Could not open database", err)
 		return
 	}
 	defer db.Close()
 
 	err = deleteMovie(db, imdbID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, r, http.StatusNotFound, "Movie not found", err)
 		return
 	}
 
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// imdbCSVColumns are the columns importIMDbCSV expects in an IMDb
+// watchlist or ratings export. Const and Title are required; the rest are
+// optional and simply left blank in the stored row if missing.
+var imdbCSVColumns = []string{"Const", "Title"}
+
+// importIMDbCSV parses an IMDb watchlist or ratings export (as downloaded
+// from imdb.com/list/watchlist or imdb.com/user/.../ratings) and upserts a
+// movie row per record, keyed by the Const column (the IMDb ID). It
+// returns how many rows were added or updated, how many were skipped
+// because they were missing a required column or failed to upsert, and the
+// reason each skipped row was skipped. It returns an error if the header
+// can't be read, lacks a required column, or a later record can't be parsed.
+func importIMDbCSV(db *sql.DB, r io.Reader) (added, skipped int, errs []string, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	column := make(map[string]int, len(header))
+	for i, name := range header {
+		column[name] = i
+	}
+	for _, name := range imdbCSVColumns {
+		if _, ok := column[name]; !ok {
+			return 0, 0, nil, fmt.Errorf("missing required column: %s", name)
+		}
+	}
+
+	line := 1 // header was line 1; the first data row is line 2
+	for {
+		record, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		line++
+		if readErr != nil {
+			return added, skipped, errs, readErr
+		}
+
+		if rowErr := upsertIMDbCSVRow(db, record, column); rowErr != nil {
+			skipped++
+			errs = append(errs, fmt.Sprintf("line %d: %s", line, rowErr))
+			continue
+		}
+		added++
+	}
+
+	return added, skipped, errs, nil
+}
+
+// field returns the trimmed value of the named column in record, or the
+// empty string if the column wasn't present in the CSV header.
+func field(record []string, column map[string]int, name string) string {
+	i, ok := column[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// upsertIMDbCSVRow inserts or updates the movie described by a single CSV
+// record. It returns an error if the row is missing a required field or
+// the database write fails.
+func upsertIMDbCSVRow(db *sql.DB, record []string, column map[string]int) error {
+	imdbID := field(record, column, "Const")
+	title := field(record, column, "Title")
+	if imdbID == "" || title == "" {
+		return fmt.Errorf("row is missing Const or Title")
+	}
+
+	year, _ := strconv.Atoi(field(record, column, "Year"))
+	rating, _ := strconv.ParseFloat(field(record, column, "IMDb Rating"), 64)
+
+	var userRating NullFloat64
+	if raw := field(record, column, "Your Rating"); raw != "" {
+		if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+			userRating = NullFloat64{sql.NullFloat64{Float64: parsed, Valid: true}}
+		}
+	}
+
+	stmt, err := db.Prepare(`INSERT INTO movies
+		(IMDb_id, Title, Year, Rating, UserRating, DateRated, Type, Genre, Director)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(IMDb_id) DO UPDATE SET
+			Title = excluded.Title,
+			Year = excluded.Year,
+			Rating = excluded.Rating,
+			UserRating = COALESCE(excluded.UserRating, movies.UserRating),
+			DateRated = COALESCE(excluded.DateRated, movies.DateRated),
+			Type = COALESCE(movies.Type, excluded.Type),
+			Genre = COALESCE(movies.Genre, excluded.Genre),
+			Director = COALESCE(movies.Director, excluded.Director)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(
+		imdbID, title, year, rating, userRating,
+		toNullString(field(record, column, "Date Rated")),
+		toNullString(field(record, column, "Title Type")),
+		toNullString(field(record, column, "Genres")),
+		toNullString(field(record, column, "Directors")),
+	)
+	return err
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// globalJobQueue is the JobQueue used by the HTTP server and the CLI. It's
+// created by startServer or main once a database connection is available.
+var globalJobQueue *JobQueue
+
+// uuidV4 generates a random RFC 4122 version 4 UUID string, e.g.
+// "f47ac10b-58cc-4372-a567-0e02b2c3d479". We roll our own instead of
+// pulling in a UUID library since a job ID only needs to be unique, not
+// spec-perfect.
+func uuidV4() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("job-%d", time.Now().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// JobKind identifies the kind of work a Job performs.
+type JobKind string
+
+const (
+	JobKindFetchPosters   JobKind = "fetch_posters"
+	JobKindEnrichMetadata JobKind = "enrich_metadata"
+)
+
+// JobStatus is the lifecycle state of a Job.
+type JobStatus string
+
+const (
+	JobStatusQueued  JobStatus = "queued"
+	JobStatusRunning JobStatus = "running"
+	JobStatusDone    JobStatus = "done"
+	JobStatusFailed  JobStatus = "failed"
+)
+
+// JobProgress reports how far a running Job has gotten.
+type JobProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// Job is a unit of work tracked by the JobQueue, such as fetching posters
+// or enriching metadata for a batch of movies. Jobs are persisted to the
+// jobs table so their state survives the process that enqueued them.
+type Job struct {
+	ID         string      `json:"id"`
+	Kind       JobKind     `json:"kind"`
+	Status     JobStatus   `json:"status"`
+	Limit      int         `json:"limit"`
+	Progress   JobProgress `json:"progress"`
+	LastError  string      `json:"lastError,omitempty"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	StartedAt  *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+}
+
+// JobQueue runs Jobs on a pool of worker goroutines and persists their
+// state in SQLite. It also keeps a registry of per-job subscriber channels
+// so handleJobStream can push progress updates over SSE.
+type JobQueue struct {
+	db *sql.DB
+
+	mu          sync.Mutex
+	jobs        map[string]*Job
+	subscribers map[string][]chan Job
+
+	pending chan string
+}
+
+// NewJobQueue creates the jobs table if it doesn't exist yet, hydrates its
+// in-memory job map from any rows already in it (so jobs enqueued by a
+// previous process are still visible to GET /jobs after a restart), and
+// starts workerCount goroutines pulling jobs off the internal queue.
+func NewJobQueue(db *sql.DB, workerCount int) (*JobQueue, error) {
+	if err := ensureJobsTable(db); err != nil {
+		return nil, err
+	}
+
+	q := &JobQueue{
+		db:          db,
+		jobs:        make(map[string]*Job),
+		subscribers: make(map[string][]chan Job),
+		pending:     make(chan string, 256),
+	}
+
+	if err := q.loadJobs(); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < workerCount; i++ {
+		go q.worker()
+	}
+
+	return q, nil
+}
+
+// loadJobs populates q.jobs from every row already in the jobs table. It
+// does not re-enqueue queued or running jobs for execution; it only
+// restores their state so they can still be read back via Get and List.
+func (q *JobQueue) loadJobs() error {
+	rows, err := q.db.Query(`SELECT
+		ID, Kind, Status, Limit_, ProgressDone, ProgressTotal, LastError,
+		CreatedAt, StartedAt, FinishedAt
+		FROM jobs`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job Job
+		var lastError sql.NullString
+		var startedAt, finishedAt sql.NullTime
+
+		if err := rows.Scan(
+			&job.ID, &job.Kind, &job.Status, &job.Limit,
+			&job.Progress.Done, &job.Progress.Total, &lastError,
+			&job.CreatedAt, &startedAt, &finishedAt,
+		); err != nil {
+			return err
+		}
+
+		job.LastError = lastError.String
+		if startedAt.Valid {
+			job.StartedAt = &startedAt.Time
+		}
+		if finishedAt.Valid {
+			job.FinishedAt = &finishedAt.Time
+		}
+
+		q.jobs[job.ID] = &job
+	}
+	return rows.Err()
+}
+
+// ensureJobsTable creates the jobs table if it doesn't already exist.
+func ensureJobsTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS jobs (
+		ID TEXT PRIMARY KEY,
+		Kind TEXT,
+		Status TEXT,
+		Limit_ INTEGER,
+		ProgressDone INTEGER,
+		ProgressTotal INTEGER,
+		LastError TEXT,
+		CreatedAt DATETIME,
+		StartedAt DATETIME,
+		FinishedAt DATETIME
+	)`)
+	return err
+}
+
+// Enqueue creates a new queued Job of the given kind, persists it, and
+// schedules it to run on the worker pool. It returns the created Job.
+func (q *JobQueue) Enqueue(kind JobKind, limit int) (*Job, error) {
+	job := &Job{
+		ID:        uuidV4(),
+		Kind:      kind,
+		Status:    JobStatusQueued,
+		Limit:     limit,
+		CreatedAt: time.Now(),
+	}
+
+	if err := q.persist(job); err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	q.jobs[job.ID] = job
+	q.mu.Unlock()
+
+	q.pending <- job.ID
+	return job, nil
+}
+
+// Get returns the job with the given ID and whether it was found.
+func (q *JobQueue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// List returns every job known to the queue, in no particular order.
+func (q *JobQueue) List() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+// Subscribe registers a channel that receives a copy of the job's state
+// every time it changes. Updates are sent without blocking, so one is
+// dropped if the channel's buffer is full. The caller must call
+// Unsubscribe when done.
+func (q *JobQueue) Subscribe(id string) chan Job {
+	ch := make(chan Job, 8)
+	q.mu.Lock()
+	q.subscribers[id] = append(q.subscribers[id], ch)
+	q.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe removes a channel previously returned by Subscribe and
+// closes it.
+func (q *JobQueue) Unsubscribe(id string, ch chan Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	subs := q.subscribers[id]
+	for i, sub := range subs {
+		if sub == ch {
+			q.subscribers[id] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	close(ch)
+}
+
+// worker pulls job IDs off the pending channel and runs them one at a
+// time. The pending channel is never closed, so a worker runs for the life
+// of the process.
+func (q *JobQueue) worker() {
+	for id := range q.pending {
+		q.run(id)
+	}
+}
+
+// run executes the job with the given ID, updating its status and
+// progress as it goes and persisting the final state.
+func (q *JobQueue) run(id string) {
+	job := q.updateJob(id, func(job *Job) {
+		now := time.Now()
+		job.Status = JobStatusRunning
+		job.StartedAt = &now
+	})
+	if job == nil {
+		return
+	}
+
+	onProgress := func(done, total int) {
+		q.updateJob(id, func(job *Job) {
+			job.Progress = JobProgress{Done: done, Total: total}
+		})
+	}
+
+	var err error
+	switch job.Kind {
+	case JobKindFetchPosters:
+		err = fetchPostersConcurrently(q.db, 3, job.Limit, onProgress)
+	case JobKindEnrichMetadata:
+		err = enrichMoviesConcurrently(q.db, 3, job.Limit, onProgress)
+	default:
+		err = fmt.Errorf("unsupported job kind: %s", job.Kind)
+	}
+
+	q.updateJob(id, func(job *Job) {
+		now := time.Now()
+		job.FinishedAt = &now
+		if err != nil {
+			job.Status = JobStatusFailed
+			job.LastError = err.Error()
+		} else {
+			job.Status = JobStatusDone
+		}
+	})
+}
+
+// updateJob applies mutate to the job with the given ID, persists the
+// result, notifies subscribers, and returns the updated job. It returns
+// nil if the job isn't known to the queue.
+func (q *JobQueue) updateJob(id string, mutate func(*Job)) *Job {
+	q.mu.Lock()
+	job, ok := q.jobs[id]
+	if !ok {
+		q.mu.Unlock()
+		return nil
+	}
+	mutate(job)
+	snapshot := *job
+	subs := append([]chan Job{}, q.subscribers[id]...)
+	q.mu.Unlock()
+
+	if err := q.persist(&snapshot); err != nil {
+		fmt.Println("Error persisting job:", err)
+	}
+
+	for _, ch := range subs {
+		select {
+		case ch <- snapshot:
+		default:
+		}
+	}
+
+	return &snapshot
+}
+
+// persist writes the job's current state to the jobs table, inserting or
+// replacing the row as needed.
+func (q *JobQueue) persist(job *Job) error {
+	_, err := q.db.Exec(`INSERT OR REPLACE INTO jobs
+		(ID, Kind, Status, Limit_, ProgressDone, ProgressTotal, LastError, CreatedAt, StartedAt, FinishedAt)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.ID, job.Kind, job.Status, job.Limit, job.Progress.Done, job.Progress.Total,
+		job.LastError, job.CreatedAt, job.StartedAt, job.FinishedAt)
+	return err
+}
+
+// handleEnqueueJob handles HTTP POST requests to /jobs. It expects a JSON
+// body of the form {"kind": "fetch_posters", "limit": 10} and enqueues a
+// matching job. It returns the created job with HTTP status 202 Accepted.
+func handleEnqueueJob(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Kind  JobKind `json:"kind"`
+		Limit int     `json:"limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, http.StatusBadRequest, "Invalid input", err)
+		return
+	}
+
+	switch body.Kind {
+	case JobKindFetchPosters, JobKindEnrichMetadata:
+	default:
+		writeError(w, r, http.StatusBadRequest, "Unknown job kind", nil)
+		return
+	}
+
+	job, err := globalJobQueue.Enqueue(body.Kind, body.Limit)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "Could not enqueue job", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// handleListJobs handles HTTP GET requests to /jobs. It returns every job
+// known to the queue in JSON format.
+func handleListJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(globalJobQueue.List())
+}
+
+// handleGetJob handles HTTP GET requests to /jobs/{id}. It returns the
+// matching job in JSON format, or a 404 if no job with that ID exists.
+func handleGetJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	job, ok := globalJobQueue.Get(id)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "Job not found", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// handleJobStream handles HTTP GET requests to /jobs/{id}/stream. It
+// upgrades the connection to Server-Sent Events and pushes the job's state
+// every time it changes, closing the stream once the job is done or
+// failed.
+func handleJobStream(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	job, ok := globalJobQueue.Get(id)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "Job not found", nil)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, "Streaming unsupported", nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	writeEvent := func(job Job) {
+		data, _ := json.Marshal(job)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+	writeEvent(job)
+
+	if job.Status == JobStatusDone || job.Status == JobStatusFailed {
+		return
+	}
+
+	updates := globalJobQueue.Subscribe(id)
+	defer globalJobQueue.Unsubscribe(id, updates)
+
+	for {
+		select {
+		case job, ok := <-updates:
+			if !ok {
+				return
+			}
+			writeEvent(job)
+			if job.Status == JobStatusDone || job.Status == JobStatusFailed {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
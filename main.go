@@ -25,6 +25,17 @@ import (
 //	delete: Deletes a movie with the given IMDb ID from the database. The
 //	        --imdbid flag is required.
 //
+//	enrich: Refreshes full OMDb metadata for a movie. If --imdbid is given,
+//	        enriches that single movie. Otherwise enriches up to --limit
+//	        movies that haven't been enriched yet.
+//
+//	search: Full-text searches movies by title, plot, actors, genre, and
+//	        director. The --q flag is required. The --limit and --offset
+//	        flags can be used to paginate.
+//
+//	import: Imports movies from an IMDb watchlist or ratings CSV export.
+//	        The --file flag is required.
+//
 // If no subcommand is provided, the server is started.
 func main() {
 	arguments := os.Args[1:] // The first element is the path to the command, so we can skip that
@@ -49,6 +60,18 @@ func main() {
 	fetchPostersCommand := flag.NewFlagSet("posters", flag.ExitOnError)
 	posterLimit := fetchPostersCommand.Int("limit", 10, "The maximum number of movies to fetch posters for")
 
+	enrichCommand := flag.NewFlagSet("enrich", flag.ExitOnError)
+	enrichImdbId := enrichCommand.String("imdbid", "", "The IMDb ID of a single movie to enrich. If omitted, enriches up to --limit un-enriched movies")
+	enrichLimit := enrichCommand.Int("limit", 10, "The maximum number of movies to enrich when --imdbid is not given")
+
+	searchCommand := flag.NewFlagSet("search", flag.ExitOnError)
+	searchQuery := searchCommand.String("q", "", "The search terms to match against title, plot, actors, genre, and director")
+	searchLimit := searchCommand.Int("limit", 20, "The maximum number of results to return")
+	searchOffset := searchCommand.Int("offset", 0, "The number of results to skip")
+
+	importCommand := flag.NewFlagSet("import", flag.ExitOnError)
+	importFile := importCommand.String("file", "", "Path to an IMDb watchlist or ratings CSV export")
+
 	if len(arguments) == 0 {
 		startServer()
 		return
@@ -60,6 +83,11 @@ func main() {
 	}
 	defer db.Close()
 
+	globalJobQueue, err = NewJobQueue(db, 3)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	switch arguments[0] {
 	case "add":
 		addCommand.Parse(arguments[1:])
@@ -74,6 +102,30 @@ func main() {
 		listCommand.Parse(arguments[1:])
 		handleListMoviesCLI(db, *sortBy, *order, *filterYear)
 
+	case "enrich":
+		enrichCommand.Parse(arguments[1:])
+		if *enrichImdbId != "" {
+			handleEnrichMovieCLI(db, *enrichImdbId)
+		} else {
+			handleEnrichMoviesCLI(db, *enrichLimit)
+		}
+
+	case "search":
+		searchCommand.Parse(arguments[1:])
+		if *searchQuery == "" {
+			fmt.Println("Search terms are required for 'search'")
+			os.Exit(1)
+		}
+		handleSearchMoviesCLI(db, *searchQuery, *searchLimit, *searchOffset)
+
+	case "import":
+		importCommand.Parse(arguments[1:])
+		if *importFile == "" {
+			fmt.Println("--file is required for 'import'")
+			os.Exit(1)
+		}
+		handleImportMoviesCLI(db, *importFile)
+
 	case "details":
 		detailsCommand.Parse(arguments[1:])
 		if *detailsImdbId == "" {
@@ -91,7 +143,7 @@ func main() {
 		handleDeleteMovieCLI(db, *deleteImdbId)
 
 	default:
-		fmt.Println("Expected 'add', 'list', 'details', 'delete' or 'posters' subcommands")
+		fmt.Println("Expected 'add', 'list', 'details', 'delete', 'posters', 'enrich', 'search' or 'import' subcommands")
 		os.Exit(1)
 	}
 }
@@ -100,12 +152,37 @@ func main() {
 // adding a movie, listing movies, getting the details of a movie, and
 // deleting a movie.
 func startServer() {
+	db, err := openDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	globalJobQueue, err = NewJobQueue(db, 3)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	router := mux.NewRouter()
+	router.Use(requestID)
+	router.Use(logging)
 	router.Use(enableCORS)
+	// gorilla/mux doesn't run router.Use middleware for NotFoundHandler, so
+	// wrap it with requestID/logging directly to keep 404s consistent with
+	// every other response.
+	router.NotFoundHandler = requestID(logging(http.HandlerFunc(notFoundHandler)))
 	router.HandleFunc("/movies", handleAddMovie).Methods("POST")
 	router.HandleFunc("/movies", handleListMovies).Methods("GET")
+	router.HandleFunc("/movies/search", handleSearchMovies).Methods("GET")
 	router.HandleFunc("/movies/{imdbID}", handleMovieDetails).Methods("GET")
 	router.HandleFunc("/movies/{imdbID}", handleDeleteMovie).Methods("DELETE")
+	router.HandleFunc("/movies/{imdbID}/refresh", handleRefreshMovieMetadata).Methods("POST")
+	router.HandleFunc("/movies/{imdbID}/reviews", handleMovieReviews).Methods("GET")
+	router.HandleFunc("/movies/import", handleImportMovies).Methods("POST")
+	router.HandleFunc("/jobs", handleEnqueueJob).Methods("POST")
+	router.HandleFunc("/jobs", handleListJobs).Methods("GET")
+	router.HandleFunc("/jobs/{id}", handleGetJob).Methods("GET")
+	router.HandleFunc("/jobs/{id}/stream", handleJobStream).Methods("GET")
 
 	fmt.Println("Starting server on :8090")
 	log.Fatal(http.ListenAndServe(":8090", router))
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// contextKey namespaces values stored on a request's context so they don't
+// collide with keys set by other packages.
+type contextKey string
+
+const requestIDContextKey contextKey = "requestID"
+
+// requestID middleware assigns a UUID to every incoming request, stores it
+// on the request's context, and echoes it back on the X-Request-ID
+// response header so a client can correlate its request with server logs
+// and error responses.
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := uuidV4()
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestIDFromContext returns the request ID assigned by the requestID
+// middleware, or the empty string if none is present.
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// statusRecordingWriter wraps an http.ResponseWriter to capture the status
+// code written, so logging middleware can report it after the handler runs.
+type statusRecordingWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecordingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush forwards to the underlying ResponseWriter's Flush method, if it has
+// one, so handlers behind this middleware (like the job-stream SSE
+// endpoint) can still type-assert their way to an http.Flusher.
+func (w *statusRecordingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying ResponseWriter, letting helpers like
+// http.ResponseController see through this wrapper.
+func (w *statusRecordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// logging middleware emits a structured log line for every request, with
+// the method, path, status code, duration, and request ID.
+func logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusRecordingWriter{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(sw, r)
+
+		slog.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", sw.status,
+			"duration", time.Since(start),
+			"request_id", requestIDFromContext(r.Context()),
+		)
+	})
+}
+
+// writeError writes a JSON error response with the given HTTP status,
+// message, and request ID (taken from r's context). err's message is
+// included separately from message so clients can show a friendly message
+// while still having the underlying detail to log. err may be nil.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string, err error) {
+	var errText string
+	if err != nil {
+		errText = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Message   string `json:"message"`
+		Error     string `json:"error,omitempty"`
+		RequestID string `json:"request_id"`
+	}{
+		Message:   message,
+		Error:     errText,
+		RequestID: requestIDFromContext(r.Context()),
+	})
+}
+
+// notFoundHandler handles requests that don't match any registered route,
+// returning a JSON 404 instead of gorilla/mux's default plain-text body.
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, "Not found", nil)
+}
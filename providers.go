@@ -0,0 +1,475 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// MovieDetails is the provider-agnostic shape of a movie's full metadata,
+// independent of which MetadataProvider produced it.
+type MovieDetails struct {
+	Title      string
+	Poster     string
+	Rated      string
+	Released   string
+	Runtime    string
+	Genre      string
+	Director   string
+	Writer     string
+	Actors     string
+	Plot       string
+	Language   string
+	Country    string
+	Awards     string
+	Metascore  string
+	ImdbRating string
+	ImdbVotes  string
+	Type       string
+	Ratings    []OMDBRating
+}
+
+// Review is a single user or critic review for a movie, independent of
+// which MetadataProvider produced it.
+type Review struct {
+	Author string
+	Rating NullFloat64
+	Text   string
+}
+
+// MetadataProvider is implemented by anything that can look up poster,
+// detail, and review data for a movie by its IMDb ID. fetchPoster is built
+// on top of this interface via defaultProvider; refreshMovieMetadata calls
+// OMDBProvider's raw OMDb lookup directly instead.
+type MetadataProvider interface {
+	FetchPoster(imdbID string) (string, error)
+	FetchDetails(imdbID string) (MovieDetails, error)
+	FetchReviews(imdbID string) ([]Review, error)
+}
+
+// omdbAPIKey returns the OMDb API key to use, preferring the OMDB_API_KEY
+// environment variable and falling back to the key baked in for local
+// development.
+func omdbAPIKey() string {
+	if key := os.Getenv("OMDB_API_KEY"); key != "" {
+		return key
+	}
+	return OMDB_API_KEY
+}
+
+// OMDBProvider is a MetadataProvider backed by the OMDb API. It's the
+// provider fetchPoster and refreshMovieMetadata have always used.
+type OMDBProvider struct {
+	APIKey string
+}
+
+// NewOMDBProvider returns an OMDBProvider configured from the
+// OMDB_API_KEY environment variable.
+func NewOMDBProvider() *OMDBProvider {
+	return &OMDBProvider{APIKey: omdbAPIKey()}
+}
+
+func (p *OMDBProvider) FetchPoster(imdbID string) (string, error) {
+	details, err := p.fetchRaw(imdbID)
+	if err != nil {
+		return "", err
+	}
+	if details.Poster == "" || strings.EqualFold(details.Poster, "n/a") {
+		return "", fmt.Errorf("poster not found")
+	}
+	return details.Poster, nil
+}
+
+func (p *OMDBProvider) FetchDetails(imdbID string) (MovieDetails, error) {
+	details, err := p.fetchRaw(imdbID)
+	if err != nil {
+		return MovieDetails{}, err
+	}
+	return MovieDetails{
+		Title: details.Title, Poster: details.Poster, Rated: details.Rated,
+		Released: details.Released, Runtime: details.Runtime, Genre: details.Genre,
+		Director: details.Director, Writer: details.Writer, Actors: details.Actors,
+		Plot: details.Plot, Language: details.Language, Country: details.Country,
+		Awards: details.Awards, Metascore: details.Metascore, ImdbRating: details.ImdbRating,
+		ImdbVotes: details.ImdbVotes, Type: details.Type, Ratings: details.Ratings,
+	}, nil
+}
+
+// FetchReviews always fails: OMDb's API doesn't expose review text, only
+// aggregate scores (already covered by FetchDetails.Ratings).
+func (p *OMDBProvider) FetchReviews(imdbID string) ([]Review, error) {
+	return nil, fmt.Errorf("omdb: reviews are not available")
+}
+
+// fetchRaw hits the OMDb API and decodes the raw response, using the
+// provider's configured API key.
+func (p *OMDBProvider) fetchRaw(imdbID string) (OMDBAPIResponse, error) {
+	apiURL := fmt.Sprintf("http://www.omdbapi.com/?i=%s&apikey=%s", imdbID, p.APIKey)
+
+	resp, err := http.Get(apiURL)
+	if err != nil {
+		return OMDBAPIResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return OMDBAPIResponse{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var result OMDBAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return OMDBAPIResponse{}, err
+	}
+	if result.Title == "" {
+		return OMDBAPIResponse{}, fmt.Errorf("movie details not found")
+	}
+	return result, nil
+}
+
+// tmdbFindResponse is the subset of TMDb's /find response we care about.
+type tmdbFindResponse struct {
+	MovieResults []struct {
+		ID          int     `json:"id"`
+		Title       string  `json:"title"`
+		PosterPath  string  `json:"poster_path"`
+		Overview    string  `json:"overview"`
+		ReleaseDate string  `json:"release_date"`
+		VoteAverage float64 `json:"vote_average"`
+		VoteCount   int     `json:"vote_count"`
+	} `json:"movie_results"`
+}
+
+type tmdbReviewsResponse struct {
+	Results []struct {
+		Author        string `json:"author"`
+		Content       string `json:"content"`
+		AuthorDetails struct {
+			Rating *float64 `json:"rating"`
+		} `json:"author_details"`
+	} `json:"results"`
+}
+
+const tmdbImageBaseURL = "https://image.tmdb.org/t/p/original"
+
+// TMDBProvider is a MetadataProvider backed by TMDb, used as a fallback for
+// IMDb IDs that OMDb can't resolve or is rate-limited for.
+type TMDBProvider struct {
+	Token string
+}
+
+// NewTMDBProvider returns a TMDBProvider configured from the TMDB_TOKEN
+// environment variable.
+func NewTMDBProvider() *TMDBProvider {
+	return &TMDBProvider{Token: os.Getenv("TMDB_TOKEN")}
+}
+
+// findByIMDbID resolves an IMDb ID to its TMDb movie entry via TMDb's
+// /find endpoint, which accepts external IDs directly.
+func (p *TMDBProvider) findByIMDbID(imdbID string) (tmdbFindResponse, error) {
+	if p.Token == "" {
+		return tmdbFindResponse{}, fmt.Errorf("tmdb: TMDB_TOKEN is not set")
+	}
+
+	apiURL := fmt.Sprintf("https://api.themoviedb.org/3/find/%s?external_source=imdb_id", imdbID)
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return tmdbFindResponse{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.Token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return tmdbFindResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return tmdbFindResponse{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var result tmdbFindResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return tmdbFindResponse{}, err
+	}
+	if len(result.MovieResults) == 0 {
+		return tmdbFindResponse{}, fmt.Errorf("movie not found on tmdb")
+	}
+	return result, nil
+}
+
+func (p *TMDBProvider) FetchPoster(imdbID string) (string, error) {
+	result, err := p.findByIMDbID(imdbID)
+	if err != nil {
+		return "", err
+	}
+	posterPath := result.MovieResults[0].PosterPath
+	if posterPath == "" {
+		return "", fmt.Errorf("poster not found")
+	}
+	return tmdbImageBaseURL + posterPath, nil
+}
+
+func (p *TMDBProvider) FetchDetails(imdbID string) (MovieDetails, error) {
+	result, err := p.findByIMDbID(imdbID)
+	if err != nil {
+		return MovieDetails{}, err
+	}
+	movie := result.MovieResults[0]
+
+	details := MovieDetails{
+		Title:      movie.Title,
+		Plot:       movie.Overview,
+		Released:   movie.ReleaseDate,
+		ImdbRating: strconv.FormatFloat(movie.VoteAverage, 'f', 1, 64),
+	}
+	if movie.PosterPath != "" {
+		details.Poster = tmdbImageBaseURL + movie.PosterPath
+	}
+	return details, nil
+}
+
+// FetchReviews fetches user reviews for the movie from TMDb's
+// /movie/{id}/reviews endpoint.
+func (p *TMDBProvider) FetchReviews(imdbID string) ([]Review, error) {
+	result, err := p.findByIMDbID(imdbID)
+	if err != nil {
+		return nil, err
+	}
+	tmdbID := result.MovieResults[0].ID
+
+	apiURL := fmt.Sprintf("https://api.themoviedb.org/3/movie/%d/reviews", tmdbID)
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.Token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var reviewsResp tmdbReviewsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reviewsResp); err != nil {
+		return nil, err
+	}
+
+	reviews := make([]Review, 0, len(reviewsResp.Results))
+	for _, r := range reviewsResp.Results {
+		review := Review{Author: r.Author, Text: r.Content}
+		if r.AuthorDetails.Rating != nil {
+			review.Rating = NullFloat64{sql.NullFloat64{Float64: *r.AuthorDetails.Rating, Valid: true}}
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
+
+// IMDBScraper is a MetadataProvider that scrapes IMDb's own review pages.
+// IMDb has no public review API, so this is the only way to get review
+// text and star ratings straight from the source. It only implements
+// FetchReviews; poster and detail lookups are left to OMDb/TMDb.
+type IMDBScraper struct {
+	HTTPClient *http.Client
+}
+
+// NewIMDBScraper returns an IMDBScraper using http.DefaultClient.
+func NewIMDBScraper() *IMDBScraper {
+	return &IMDBScraper{HTTPClient: http.DefaultClient}
+}
+
+func (s *IMDBScraper) FetchPoster(imdbID string) (string, error) {
+	return "", fmt.Errorf("imdb scraper: poster lookup is not supported")
+}
+
+func (s *IMDBScraper) FetchDetails(imdbID string) (MovieDetails, error) {
+	return MovieDetails{}, fmt.Errorf("imdb scraper: detail lookup is not supported")
+}
+
+// FetchReviews scrapes https://www.imdb.com/title/{imdbID}/reviews and
+// extracts each review's text and star rating.
+func (s *IMDBScraper) FetchReviews(imdbID string) ([]Review, error) {
+	pageURL := fmt.Sprintf("https://www.imdb.com/title/%s/reviews", imdbID)
+
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; mpp-go/1.0)")
+
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var reviews []Review
+	doc.Find(".review-container").Each(func(_ int, sel *goquery.Selection) {
+		text := strings.TrimSpace(sel.Find(".text.show-more__control").Text())
+		if text == "" {
+			return
+		}
+
+		review := Review{Text: text}
+		review.Author = strings.TrimSpace(sel.Find(".display-name-link a").Text())
+
+		ratingText := strings.TrimSpace(sel.Find(".rating-other-user-rating span").First().Text())
+		if rating, err := strconv.ParseFloat(ratingText, 64); err == nil {
+			review.Rating = NullFloat64{sql.NullFloat64{Float64: rating, Valid: true}}
+		}
+
+		reviews = append(reviews, review)
+	})
+
+	if len(reviews) == 0 {
+		return nil, fmt.Errorf("no reviews found")
+	}
+	return reviews, nil
+}
+
+// ChainProvider tries each MetadataProvider in order, returning the first
+// successful result. It's used to fall back from OMDb to TMDb (and to the
+// IMDb scraper for reviews) when the primary provider is rate-limited or
+// missing data for a given IMDb ID.
+type ChainProvider struct {
+	Providers []MetadataProvider
+}
+
+// NewChainProvider returns a ChainProvider that tries providers in the
+// given order.
+func NewChainProvider(providers ...MetadataProvider) *ChainProvider {
+	return &ChainProvider{Providers: providers}
+}
+
+func (c *ChainProvider) FetchPoster(imdbID string) (string, error) {
+	var lastErr error
+	for _, provider := range c.Providers {
+		poster, err := provider.FetchPoster(imdbID)
+		if err == nil {
+			return poster, nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+func (c *ChainProvider) FetchDetails(imdbID string) (MovieDetails, error) {
+	var lastErr error
+	for _, provider := range c.Providers {
+		details, err := provider.FetchDetails(imdbID)
+		if err == nil {
+			return details, nil
+		}
+		lastErr = err
+	}
+	return MovieDetails{}, lastErr
+}
+
+func (c *ChainProvider) FetchReviews(imdbID string) ([]Review, error) {
+	var lastErr error
+	for _, provider := range c.Providers {
+		reviews, err := provider.FetchReviews(imdbID)
+		if err == nil {
+			return reviews, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// defaultProvider is the MetadataProvider used by fetchPoster and
+// fetchAndStoreReviews: OMDb/TMDb for details and posters, falling
+// back to the IMDb scraper for reviews neither of them can supply.
+var defaultProvider MetadataProvider = NewChainProvider(NewOMDBProvider(), NewTMDBProvider(), NewIMDBScraper())
+
+// ensureReviewsTable creates the reviews table if it doesn't already exist.
+func ensureReviewsTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS reviews (
+		ID INTEGER PRIMARY KEY AUTOINCREMENT,
+		IMDb_id TEXT,
+		Author TEXT,
+		Rating REAL,
+		Text TEXT
+	)`)
+	return err
+}
+
+// fetchAndStoreReviews fetches reviews for imdbID via defaultProvider and
+// replaces any previously stored reviews for that movie.
+func fetchAndStoreReviews(db *sql.DB, imdbID string) ([]Review, error) {
+	reviews, err := defaultProvider.FetchReviews(imdbID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM reviews WHERE IMDb_id = ?", imdbID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO reviews (IMDb_id, Author, Rating, Text) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for _, review := range reviews {
+		if _, err := stmt.Exec(imdbID, review.Author, review.Rating, review.Text); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// listReviews returns the reviews stored for the movie with the given
+// IMDb ID.
+func listReviews(db *sql.DB, imdbID string) ([]Review, error) {
+	rows, err := db.Query("SELECT Author, Rating, Text FROM reviews WHERE IMDb_id = ?", imdbID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []Review
+	for rows.Next() {
+		var review Review
+		if err := rows.Scan(&review.Author, &review.Rating, &review.Text); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
@@ -4,20 +4,75 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+type OMDBRating struct {
+	Source string `json:"Source"`
+	Value  string `json:"Value"`
+}
+
 type OMDBAPIResponse struct {
-	Poster string `json:"Poster"`
+	Title      string       `json:"Title"`
+	Year       string       `json:"Year"`
+	Rated      string       `json:"Rated"`
+	Released   string       `json:"Released"`
+	Runtime    string       `json:"Runtime"`
+	Genre      string       `json:"Genre"`
+	Director   string       `json:"Director"`
+	Writer     string       `json:"Writer"`
+	Actors     string       `json:"Actors"`
+	Plot       string       `json:"Plot"`
+	Language   string       `json:"Language"`
+	Country    string       `json:"Country"`
+	Awards     string       `json:"Awards"`
+	Poster     string       `json:"Poster"`
+	Ratings    []OMDBRating `json:"Ratings"`
+	Metascore  string       `json:"Metascore"`
+	ImdbRating string       `json:"imdbRating"`
+	ImdbVotes  string       `json:"imdbVotes"`
+	Type       string       `json:"Type"`
 }
 
 const dbPath = "./movies.db"
 const OMDB_API_KEY = "34e1747c"
 
+// metadataColumns are the columns that refreshMovieMetadata populates beyond
+// the core IMDb_id/Title/Year/Rating/Poster columns. They are added to the
+// movies table lazily by openDB so that older databases created before this
+// enrichment feature existed keep working.
+var metadataColumns = []string{
+	"Rated TEXT",
+	"Released TEXT",
+	"Runtime TEXT",
+	"Genre TEXT",
+	"Director TEXT",
+	"Writer TEXT",
+	"Actors TEXT",
+	"Plot TEXT",
+	"Language TEXT",
+	"Country TEXT",
+	"Awards TEXT",
+	"Metascore TEXT",
+	"ImdbRating REAL",
+	"ImdbVotes TEXT",
+	"Type TEXT",
+	"Ratings TEXT",
+}
+
+// importColumns are the columns populated by importIMDbCSV beyond the
+// columns OMDb enrichment already adds. They're added to the movies table
+// lazily by openDB, the same way metadataColumns are.
+var importColumns = []string{
+	"UserRating REAL",
+	"DateRated TEXT",
+}
+
 // openDB opens a database connection to the SQLite database file at
 // dbPath. It returns the database connection and an error if the database
 // cannot be opened.
@@ -26,37 +81,215 @@ func openDB() (*sql.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	if err := ensureIMDbIDUniqueIndex(db); err != nil {
+		return nil, err
+	}
+
+	if err := ensureMetadataColumns(db); err != nil {
+		return nil, err
+	}
+
+	if err := ensureImportColumns(db); err != nil {
+		return nil, err
+	}
+
+	if err := ensureReviewsTable(db); err != nil {
+		return nil, err
+	}
+
+	if err := initFTS(db); err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
 
-// fetchPoster retrieves the poster URL for a movie from the OMDB API based on the provided IMDb ID.
-// It constructs the API request URL using the IMDb ID and the OMDB API key, and sends an HTTP GET request.
-// If the request fails or the response cannot be decoded, it returns an error.
-// If the poster is found, it returns the poster URL as a string.
-// If no poster is found, it returns an error indicating that the poster was not found.
+// ensureIMDbIDUniqueIndex creates a unique index on the movies table's
+// IMDb_id column if it doesn't already exist. upsertIMDbCSVRow's
+// ON CONFLICT(IMDb_id) clause requires a UNIQUE or PRIMARY KEY constraint
+// on that column to match against; since nothing else in this repo creates
+// the movies table, we can't assume one is already there. Older databases
+// may also predate this constraint and already contain duplicate IMDb_id
+// rows (addMovie never enforced uniqueness), so we de-duplicate first;
+// otherwise CREATE UNIQUE INDEX would fail and openDB, and with it every
+// CLI subcommand and the server, would refuse to start.
+func ensureIMDbIDUniqueIndex(db *sql.DB) error {
+	if err := dedupeMoviesByIMDbID(db); err != nil {
+		return fmt.Errorf("could not de-duplicate movies.IMDb_id before indexing it: %w", err)
+	}
+
+	_, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_movies_imdb_id ON movies(IMDb_id)`)
+	if err != nil {
+		return fmt.Errorf("could not create unique index on movies.IMDb_id: %w", err)
+	}
+	return nil
+}
+
+// dedupeMoviesByIMDbID deletes every duplicate IMDb_id row except the
+// earliest one (lowest rowid), leaving a single row per IMDb_id. This is a
+// one-time migration step: once the unique index above exists, SQLite
+// itself rejects any new duplicates.
+func dedupeMoviesByIMDbID(db *sql.DB) error {
+	_, err := db.Exec(`DELETE FROM movies WHERE rowid NOT IN (
+		SELECT MIN(rowid) FROM movies GROUP BY IMDb_id
+	)`)
+	return err
+}
+
+// ensureMetadataColumns adds the OMDb detail columns to the movies table if
+// they don't already exist.
+func ensureMetadataColumns(db *sql.DB) error {
+	return addColumnsIfMissing(db, metadataColumns)
+}
+
+// ensureImportColumns adds the IMDb CSV import columns to the movies table
+// if they don't already exist.
+func ensureImportColumns(db *sql.DB) error {
+	return addColumnsIfMissing(db, importColumns)
+}
+
+// addColumnsIfMissing adds each "name TYPE" column definition to the movies
+// table. SQLite has no "ADD COLUMN IF NOT EXISTS", so we add each column
+// and ignore the "duplicate column name" error that SQLite returns when
+// it's already there.
+func addColumnsIfMissing(db *sql.DB, columns []string) error {
+	for _, column := range columns {
+		_, err := db.Exec(fmt.Sprintf("ALTER TABLE movies ADD COLUMN %s", column))
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchPoster retrieves the poster URL for a movie based on the provided
+// IMDb ID, using defaultProvider so a fetch falls back from OMDb to TMDb
+// automatically. It returns an error if no provider in the chain has a
+// poster for the given IMDb ID.
 func fetchPoster(imdbID string) (string, error) {
-	apiURL := fmt.Sprintf("http://www.omdbapi.com/?i=%s&apikey=%s", imdbID, OMDB_API_KEY)
+	return defaultProvider.FetchPoster(imdbID)
+}
+
+// fetchMovieDetails retrieves the full OMDb record for a movie based on the
+// provided IMDb ID, including plot, cast, and the per-source Ratings array.
+// refreshMovieMetadata needs OMDb's raw fields specifically (not the
+// provider-agnostic MovieDetails), so it always goes through OMDBProvider
+// rather than defaultProvider's TMDb/scraper fallbacks.
+// It returns an error if the request fails, the response cannot be decoded,
+// or OMDb has no title for the given IMDb ID.
+func fetchMovieDetails(imdbID string) (OMDBAPIResponse, error) {
+	return NewOMDBProvider().fetchRaw(imdbID)
+}
+
+// toNullString turns a string field into a NullString, treating both the
+// empty string and OMDb's "N/A" placeholder as absent.
+func toNullString(value string) NullString {
+	if value == "" || strings.EqualFold(value, "n/a") {
+		return NullString{}
+	}
+	return NullString{sql.NullString{String: value, Valid: true}}
+}
+
+// refreshMovieMetadata fetches the full OMDb record for imdbID and stores it
+// on the matching row in the movies table, including the poster. It returns
+// an error if the OMDb lookup fails or the database cannot be updated.
+func refreshMovieMetadata(db *sql.DB, imdbID string) error {
+	details, err := fetchMovieDetails(imdbID)
+	if err != nil {
+		return err
+	}
+
+	var ratingsJSON []byte
+	if len(details.Ratings) > 0 {
+		ratingsJSON, err = json.Marshal(details.Ratings)
+		if err != nil {
+			return err
+		}
+	}
 
-	resp, err := http.Get(apiURL)
+	imdbRating := NullFloat64{}
+	if details.ImdbRating != "" && !strings.EqualFold(details.ImdbRating, "n/a") {
+		if f, err := strconv.ParseFloat(details.ImdbRating, 64); err == nil {
+			imdbRating = NullFloat64{sql.NullFloat64{Float64: f, Valid: true}}
+		}
+	}
+
+	stmt, err := db.Prepare(`UPDATE movies SET
+		Poster = ?, Rated = ?, Released = ?, Runtime = ?, Genre = ?, Director = ?,
+		Writer = ?, Actors = ?, Plot = ?, Language = ?, Country = ?, Awards = ?,
+		Metascore = ?, ImdbRating = ?, ImdbVotes = ?, Type = ?, Ratings = ?
+		WHERE IMDb_id = ?`)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer resp.Body.Close()
+	defer stmt.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	_, err = stmt.Exec(
+		toNullString(details.Poster), toNullString(details.Rated), toNullString(details.Released),
+		toNullString(details.Runtime), toNullString(details.Genre), toNullString(details.Director),
+		toNullString(details.Writer), toNullString(details.Actors), toNullString(details.Plot),
+		toNullString(details.Language), toNullString(details.Country), toNullString(details.Awards),
+		toNullString(details.Metascore), imdbRating, toNullString(details.ImdbVotes),
+		toNullString(details.Type), string(ratingsJSON), imdbID,
+	)
+	return err
+}
+
+// progressFunc reports that done of a total of total items in a concurrent
+// batch (fetchPostersConcurrently, enrichMoviesConcurrently) have been
+// processed so far. It may be nil if the caller doesn't care about
+// intermediate progress.
+type progressFunc func(done, total int)
+
+// enrichMoviesConcurrently is the enrichment analogue of
+// fetchPostersConcurrently: it looks up movies that have never been
+// enriched (no Plot on file) and refreshes their full OMDb metadata using a
+// pool of workerCount goroutines. Errors for individual IMDb IDs are
+// swallowed so one bad lookup doesn't stop the rest from being enriched.
+// onProgress, if non-nil, is called after each movie finishes processing.
+func enrichMoviesConcurrently(db *sql.DB, workerCount int, limit int, onProgress progressFunc) error {
+	rows, err := db.Query("SELECT IMDb_id FROM movies WHERE Plot IS NULL LIMIT ?", limit)
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
 
-	var result OMDBAPIResponse
+	imdbIDs := []string{}
+	for rows.Next() {
+		var imdbID string
+		if err := rows.Scan(&imdbID); err != nil {
+			return err
+		}
+		imdbIDs = append(imdbIDs, imdbID)
+	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
+	total := len(imdbIDs)
+	var done int32
+
+	var wg sync.WaitGroup
+	imdbChan := make(chan string, len(imdbIDs))
+
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			for imdbID := range imdbChan {
+				refreshMovieMetadata(db, imdbID)
+				if onProgress != nil {
+					onProgress(int(atomic.AddInt32(&done, 1)), total)
+				}
+			}
+		}(i + 1)
 	}
 
-	if result.Poster == "" || strings.ToLower(result.Poster) == "n/a" {
-		return "", fmt.Errorf("poster not found")
+	for _, imdbID := range imdbIDs {
+		imdbChan <- imdbID
 	}
-	return result.Poster, nil
+	close(imdbChan)
+
+	wg.Wait()
+	return nil
 }
 
 // updatePosterInDB updates the poster URL for the movie with the given IMDb ID in the database.
@@ -78,8 +311,9 @@ func updatePosterInDB(db *sql.DB, imdbID, posterURL string) error {
 // (as specified by workerCount) to fetch and update posters concurrently. Each worker fetches the poster
 // URL from the OMDB API using the IMDb ID and updates the database with the poster URL if found.
 // If any error occurs while fetching the poster or updating the database, it logs the error and continues with other IDs.
+// onProgress, if non-nil, is called after each movie finishes processing.
 // Returns an error if there is a problem querying the database for IMDb IDs.
-func fetchPostersConcurrently(db *sql.DB, workerCount int, limit int) error {
+func fetchPostersConcurrently(db *sql.DB, workerCount int, limit int, onProgress progressFunc) error {
 	rows, err := db.Query("SELECT IMDb_id FROM movies WHERE Poster IS NULL LIMIT ?", limit) // If there are DB issues check this line and replace IS NULL with ''
 	if err != nil {
 		return err
@@ -95,6 +329,9 @@ func fetchPostersConcurrently(db *sql.DB, workerCount int, limit int) error {
 		imdbIDs = append(imdbIDs, imdbID)
 	}
 
+	total := len(imdbIDs)
+	var done int32
+
 	var wg sync.WaitGroup
 	imdbChan := make(chan string, len(imdbIDs))
 
@@ -104,12 +341,13 @@ func fetchPostersConcurrently(db *sql.DB, workerCount int, limit int) error {
 			defer wg.Done()
 			for imdbID := range imdbChan {
 				posterURL, err := fetchPoster(imdbID)
-				if err != nil {
-					continue
+				if err == nil {
+					if err := updatePosterInDB(db, imdbID, posterURL); err != nil {
+						// continue
+					}
 				}
-
-				if err := updatePosterInDB(db, imdbID, posterURL); err != nil {
-					// continue
+				if onProgress != nil {
+					onProgress(int(atomic.AddInt32(&done, 1)), total)
 				}
 			}
 		}(i + 1)
@@ -137,9 +375,54 @@ func addMovie(db *sql.DB, imdbID, title string, year int, rating float64) error
 	return err
 }
 
+// movieColumns are the columns selected by listMovies and showMovieDetails,
+// in the order scanMovieRow expects to receive them.
+const movieColumns = `IMDb_id, Title, Year, Rating, Poster, Rated, Released, Runtime, Genre,
+	Director, Writer, Actors, Plot, Language, Country, Awards, Metascore,
+	ImdbRating, ImdbVotes, Type, Ratings, UserRating, DateRated`
+
+// movieColumnsQualified is movieColumns with every column prefixed by the
+// "m" alias, for queries like searchMovies that join the movies table
+// against another table sharing some column names.
+const movieColumnsQualified = `m.IMDb_id, m.Title, m.Year, m.Rating, m.Poster, m.Rated, m.Released, m.Runtime, m.Genre,
+	m.Director, m.Writer, m.Actors, m.Plot, m.Language, m.Country, m.Awards, m.Metascore,
+	m.ImdbRating, m.ImdbVotes, m.Type, m.Ratings, m.UserRating, m.DateRated`
+
+// movieRowScanner is satisfied by both *sql.Row and *sql.Rows, letting
+// scanMovieRow back both listMovies and showMovieDetails.
+type movieRowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMovieRow scans a row selected via movieColumns into a Movie, decoding
+// the Ratings column (stored as a JSON array) into movie.Ratings.
+func scanMovieRow(row movieRowScanner) (Movie, error) {
+	var movie Movie
+	var ratingsJSON NullString
+
+	err := row.Scan(
+		&movie.IMDb_id, &movie.Title, &movie.Year, &movie.Rating, &movie.Poster,
+		&movie.Rated, &movie.Released, &movie.Runtime, &movie.Genre,
+		&movie.Director, &movie.Writer, &movie.Actors, &movie.Plot,
+		&movie.Language, &movie.Country, &movie.Awards, &movie.Metascore,
+		&movie.ImdbRating, &movie.ImdbVotes, &movie.Type, &ratingsJSON,
+		&movie.UserRating, &movie.DateRated,
+	)
+	if err != nil {
+		return movie, err
+	}
+
+	if ratingsJSON.Valid && ratingsJSON.String != "" {
+		if err := json.Unmarshal([]byte(ratingsJSON.String), &movie.Ratings); err != nil {
+			return movie, err
+		}
+	}
+	return movie, nil
+}
+
 // listMovies retrieves a list of movies from the database. It takes the database connection, an optional field to sort by, an optional order, and an optional year to filter by. It returns the list of movies and an error. If the database cannot be opened or the movies cannot be fetched, it returns an HTTP error. Otherwise, it returns the list of movies.
 func listMovies(db *sql.DB, sortBy string, order string, filterYear int) ([]Movie, error) {
-	query := "SELECT IMDb_id, Title, Year, Rating, Poster FROM movies"
+	query := "SELECT " + movieColumns + " FROM movies"
 	var args []interface{}
 
 	if filterYear != 0 {
@@ -169,8 +452,8 @@ func listMovies(db *sql.DB, sortBy string, order string, filterYear int) ([]Movi
 
 	var movies []Movie
 	for rows.Next() {
-		var movie Movie
-		if err := rows.Scan(&movie.IMDb_id, &movie.Title, &movie.Year, &movie.Rating, &movie.Poster); err != nil {
+		movie, err := scanMovieRow(rows)
+		if err != nil {
 			return nil, err
 		}
 		movies = append(movies, movie)
@@ -184,10 +467,9 @@ func listMovies(db *sql.DB, sortBy string, order string, filterYear int) ([]Movi
 func showMovieDetails(db *sql.DB, imdbID string) (Movie, error) {
 	imdbID = strings.TrimSpace(imdbID)
 
-	var movie Movie
-	query := "SELECT IMDb_id, Title, Rating, Year, Poster FROM movies WHERE IMDb_id = ?"
+	query := "SELECT " + movieColumns + " FROM movies WHERE IMDb_id = ?"
 
-	err := db.QueryRow(query, imdbID).Scan(&movie.IMDb_id, &movie.Title, &movie.Rating, &movie.Year, &movie.Poster)
+	movie, err := scanMovieRow(db.QueryRow(query, imdbID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return movie, fmt.Errorf("Movie not found")
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// initFTS creates the movies_fts FTS5 virtual table and the triggers that
+// keep it in sync with the movies table, if they don't already exist, and
+// backfills it with every movie that was already in the table the first
+// time movies_fts is created (the insert/update/delete triggers only keep
+// it in sync with writes that happen after that). The go-sqlite3 driver
+// must be built with the sqlite_fts5 build tag (the SQLITE_ENABLE_FTS5 cgo
+// flag) for this to work.
+func initFTS(db *sql.DB) error {
+	var alreadyExists bool
+	err := db.QueryRow(`SELECT EXISTS (
+		SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'movies_fts'
+	)`).Scan(&alreadyExists)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS movies_fts USING fts5(
+		Title, Plot, Actors, Genre, Director,
+		content='movies', content_rowid='rowid'
+	)`)
+	if err != nil {
+		return err
+	}
+
+	triggers := []string{
+		`CREATE TRIGGER IF NOT EXISTS movies_fts_insert AFTER INSERT ON movies BEGIN
+			INSERT INTO movies_fts(rowid, Title, Plot, Actors, Genre, Director)
+			VALUES (new.rowid, new.Title, new.Plot, new.Actors, new.Genre, new.Director);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS movies_fts_delete AFTER DELETE ON movies BEGIN
+			INSERT INTO movies_fts(movies_fts, rowid, Title, Plot, Actors, Genre, Director)
+			VALUES ('delete', old.rowid, old.Title, old.Plot, old.Actors, old.Genre, old.Director);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS movies_fts_update AFTER UPDATE ON movies BEGIN
+			INSERT INTO movies_fts(movies_fts, rowid, Title, Plot, Actors, Genre, Director)
+			VALUES ('delete', old.rowid, old.Title, old.Plot, old.Actors, old.Genre, old.Director);
+			INSERT INTO movies_fts(rowid, Title, Plot, Actors, Genre, Director)
+			VALUES (new.rowid, new.Title, new.Plot, new.Actors, new.Genre, new.Director);
+		END`,
+	}
+
+	for _, trigger := range triggers {
+		if _, err := db.Exec(trigger); err != nil {
+			return err
+		}
+	}
+
+	if !alreadyExists {
+		_, err := db.Exec(`INSERT INTO movies_fts(rowid, Title, Plot, Actors, Genre, Director)
+			SELECT rowid, Title, Plot, Actors, Genre, Director FROM movies`)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchResult pairs a Movie with a highlighted snippet of the text that
+// matched the search query.
+type SearchResult struct {
+	Movie
+	Snippet string
+}
+
+// sanitizeFTSQuery turns free-form user input into an FTS5 query string.
+// Each whitespace-separated token is wrapped in double quotes (escaping any
+// quotes already present) so that FTS5-reserved characters like - or * in
+// the user's input are treated as literal text instead of query syntax.
+func sanitizeFTSQuery(query string) string {
+	fields := strings.Fields(query)
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		escaped := strings.ReplaceAll(field, `"`, `""`)
+		terms = append(terms, fmt.Sprintf(`"%s"`, escaped))
+	}
+	return strings.Join(terms, " ")
+}
+
+// searchMovies runs a full-text search for query across the movies' Title,
+// Plot, Actors, Genre, and Director fields, ranked by BM25 relevance. It
+// returns up to limit results starting at offset, each annotated with a
+// highlighted snippet of the matching text.
+func searchMovies(db *sql.DB, query string, limit, offset int) ([]SearchResult, error) {
+	ftsQuery := sanitizeFTSQuery(query)
+	if ftsQuery == "" {
+		return nil, nil
+	}
+
+	sqlQuery := `SELECT ` + movieColumnsQualified + `,
+		snippet(movies_fts, -1, '<mark>', '</mark>', '...', 10)
+		FROM movies_fts
+		JOIN movies m ON m.rowid = movies_fts.rowid
+		WHERE movies_fts MATCH ?
+		ORDER BY bm25(movies_fts)
+		LIMIT ? OFFSET ?`
+
+	rows, err := db.Query(sqlQuery, ftsQuery, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var ratingsJSON NullString
+		var result SearchResult
+
+		err := rows.Scan(
+			&result.IMDb_id, &result.Title, &result.Year, &result.Rating, &result.Poster,
+			&result.Rated, &result.Released, &result.Runtime, &result.Genre,
+			&result.Director, &result.Writer, &result.Actors, &result.Plot,
+			&result.Language, &result.Country, &result.Awards, &result.Metascore,
+			&result.ImdbRating, &result.ImdbVotes, &result.Type, &ratingsJSON,
+			&result.UserRating, &result.DateRated,
+			&result.Snippet,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if ratingsJSON.Valid && ratingsJSON.String != "" {
+			if err := json.Unmarshal([]byte(ratingsJSON.String), &result.Ratings); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}